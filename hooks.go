@@ -0,0 +1,237 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// BeforeCallback is invoked before a query runs. The context it returns is
+// passed on to the query and to the matching AfterCallback, so it can be
+// used to stash a start time, a span, or anything else the after-hook
+// needs.
+type BeforeCallback func(ctx context.Context, op, query string, args []interface{}) context.Context
+
+// AfterCallback is invoked once a query has run, with how long it took and
+// the error it returned, if any.
+type AfterCallback func(ctx context.Context, op, query string, args []interface{}, dur time.Duration, err error)
+
+// Hooks are called around every Exec, Prepare, Query, QueryRow, Commit and
+// Rollback made through a DB wrapped with WrapDBWithHooks. Either callback
+// may be nil. This is the seam on top of which tracing or metrics
+// integrations can be built, without forking wrappedQueryer.
+type Hooks struct {
+	Before BeforeCallback
+	After  AfterCallback
+}
+
+func (h Hooks) before(ctx context.Context, op, query string, args []interface{}) context.Context {
+	if h.Before == nil {
+		return ctx
+	}
+	return h.Before(ctx, op, query, args)
+}
+
+func (h Hooks) after(ctx context.Context, op, query string, args []interface{}, start time.Time, err error) {
+	if h.After == nil {
+		return
+	}
+	h.After(ctx, op, query, args, time.Since(start), err)
+}
+
+// WrapDBWithHooks wraps db so hooks run around every Exec, Prepare, Query,
+// QueryRow, Commit and Rollback made through it, including calls made
+// inside a Tx, through a Conn, or through a prepared Stmt.
+func WrapDBWithHooks(db *sql.DB, hooks Hooks) DB {
+	return hookedDB{db, WrapDB(db), hooks}
+}
+
+type hookedDB struct {
+	sqlDB *sql.DB
+	DB
+	hooks Hooks
+}
+
+func (db hookedDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return hookedQueryer{db.DB, db.hooks}.Exec(ctx, query, args...)
+}
+
+func (db hookedDB) Prepare(ctx context.Context, query string) (Stmt, error) {
+	return hookedQueryer{db.DB, db.hooks}.Prepare(ctx, query)
+}
+
+func (db hookedDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return hookedQueryer{db.DB, db.hooks}.Query(ctx, query, args...)
+}
+
+func (db hookedDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return hookedQueryer{db.DB, db.hooks}.QueryRow(ctx, query, args...)
+}
+
+func (db hookedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return hookedTx{tx, hookedQueryer{tx, db.hooks}, db.hooks}, nil
+}
+
+// Conn returns a Conn pinned to a single underlying connection, with hooks
+// running around every call made through it.
+func (db hookedDB) Conn(ctx context.Context) (Conn, error) {
+	c, err := db.sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := WrapConn(c)
+	return hookedConn{conn, hookedQueryer{conn, db.hooks}}, nil
+}
+
+type hookedConn struct {
+	Conn
+	queryer hookedQueryer
+}
+
+func (c hookedConn) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.queryer.Exec(ctx, query, args...)
+}
+
+func (c hookedConn) Prepare(ctx context.Context, query string) (Stmt, error) {
+	return c.queryer.Prepare(ctx, query)
+}
+
+func (c hookedConn) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return c.queryer.Query(ctx, query, args...)
+}
+
+func (c hookedConn) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return c.queryer.QueryRow(ctx, query, args...)
+}
+
+func (c hookedConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return hookedTx{tx, hookedQueryer{tx, c.queryer.hooks}, c.queryer.hooks}, nil
+}
+
+type hookedTx struct {
+	tx      Tx
+	queryer hookedQueryer
+	hooks   Hooks
+}
+
+func (tx hookedTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.queryer.Exec(ctx, query, args...)
+}
+
+func (tx hookedTx) Prepare(ctx context.Context, query string) (Stmt, error) {
+	return tx.queryer.Prepare(ctx, query)
+}
+
+func (tx hookedTx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return tx.queryer.Query(ctx, query, args...)
+}
+
+func (tx hookedTx) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return tx.queryer.QueryRow(ctx, query, args...)
+}
+
+func (tx hookedTx) Stmt(ctx context.Context, stmt Stmt) Stmt {
+	return hookedStmt{tx.tx.Stmt(ctx, stmt), tx.hooks}
+}
+
+func (tx hookedTx) Commit() error {
+	ctx := tx.hooks.before(context.Background(), "Commit", "", nil)
+	start := time.Now()
+	err := tx.tx.Commit()
+	tx.hooks.after(ctx, "Commit", "", nil, start, err)
+	return err
+}
+
+func (tx hookedTx) Rollback() error {
+	ctx := tx.hooks.before(context.Background(), "Rollback", "", nil)
+	start := time.Now()
+	err := tx.tx.Rollback()
+	tx.hooks.after(ctx, "Rollback", "", nil, start, err)
+	return err
+}
+
+// hookedQueryer wraps any Queryer, running hooks around each call.
+type hookedQueryer struct {
+	q     Queryer
+	hooks Hooks
+}
+
+func (q hookedQueryer) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx = q.hooks.before(ctx, "Exec", query, args)
+	start := time.Now()
+	res, err := q.q.Exec(ctx, query, args...)
+	q.hooks.after(ctx, "Exec", query, args, start, err)
+	return res, err
+}
+
+func (q hookedQueryer) Prepare(ctx context.Context, query string) (Stmt, error) {
+	ctx = q.hooks.before(ctx, "Prepare", query, nil)
+	start := time.Now()
+	stmt, err := q.q.Prepare(ctx, query)
+	q.hooks.after(ctx, "Prepare", query, nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return hookedStmt{stmt, q.hooks}, nil
+}
+
+func (q hookedQueryer) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	ctx = q.hooks.before(ctx, "Query", query, args)
+	start := time.Now()
+	rows, err := q.q.Query(ctx, query, args...)
+	q.hooks.after(ctx, "Query", query, args, start, err)
+	return rows, err
+}
+
+func (q hookedQueryer) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	ctx = q.hooks.before(ctx, "QueryRow", query, args)
+	start := time.Now()
+	row := q.q.QueryRow(ctx, query, args...)
+	q.hooks.after(ctx, "QueryRow", query, args, start, nil)
+	return row
+}
+
+type hookedStmt struct {
+	stmt  Stmt
+	hooks Hooks
+}
+
+func (s hookedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s hookedStmt) Unwrap() *sql.Stmt {
+	return s.stmt.Unwrap()
+}
+
+func (s hookedStmt) Exec(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	ctx = s.hooks.before(ctx, "Exec", "", args)
+	start := time.Now()
+	res, err := s.stmt.Exec(ctx, args...)
+	s.hooks.after(ctx, "Exec", "", args, start, err)
+	return res, err
+}
+
+func (s hookedStmt) Query(ctx context.Context, args ...interface{}) (Rows, error) {
+	ctx = s.hooks.before(ctx, "Query", "", args)
+	start := time.Now()
+	rows, err := s.stmt.Query(ctx, args...)
+	s.hooks.after(ctx, "Query", "", args, start, err)
+	return rows, err
+}
+
+func (s hookedStmt) QueryRow(ctx context.Context, args ...interface{}) Row {
+	ctx = s.hooks.before(ctx, "QueryRow", "", args)
+	start := time.Now()
+	row := s.stmt.QueryRow(ctx, args...)
+	s.hooks.after(ctx, "QueryRow", "", args, start, nil)
+	return row
+}
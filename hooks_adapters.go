@@ -0,0 +1,68 @@
+package sqler
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer is the subset of the OpenTelemetry trace.Tracer API that
+// TracingHooks needs. A real *trace.Tracer doesn't satisfy this directly,
+// since Start takes variadic SpanStartOptions; wrap it with a one-line
+// adapter, e.g.:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//	func (o otelTracer) Start(ctx context.Context, name string) (context.Context, sqler.Span) {
+//		ctx, span := o.t.Start(ctx, name)
+//		return ctx, span
+//	}
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is the subset of the OpenTelemetry trace.Span API that
+// TracingHooks needs; *trace.Span satisfies it as-is.
+type Span interface {
+	End()
+	RecordError(err error)
+}
+
+// TracingHooks builds Hooks that open a span named "sqler.<op>" around
+// every call and record its error, if any, on the span. Layer it on top of
+// WrapDBWithHooks to get OpenTelemetry tracing without forking
+// wrappedQueryer.
+func TracingHooks(tracer Tracer) Hooks {
+	type spanKey struct{}
+	return Hooks{
+		Before: func(ctx context.Context, op, query string, args []interface{}) context.Context {
+			ctx, span := tracer.Start(ctx, "sqler."+op)
+			return context.WithValue(ctx, spanKey{}, span)
+		},
+		After: func(ctx context.Context, op, query string, args []interface{}, dur time.Duration, err error) {
+			span, ok := ctx.Value(spanKey{}).(Span)
+			if !ok {
+				return
+			}
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		},
+	}
+}
+
+// Histogram is the subset of the Prometheus Histogram API that
+// MetricsHooks needs; a real prometheus.Histogram satisfies it as-is.
+type Histogram interface {
+	Observe(v float64)
+}
+
+// MetricsHooks builds Hooks that observe the duration, in seconds, of
+// every call on histogram. Layer it on top of WrapDBWithHooks to get
+// Prometheus latency metrics without forking wrappedQueryer.
+func MetricsHooks(histogram Histogram) Hooks {
+	return Hooks{
+		After: func(ctx context.Context, op, query string, args []interface{}, dur time.Duration, err error) {
+			histogram.Observe(dur.Seconds())
+		},
+	}
+}
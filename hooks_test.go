@@ -0,0 +1,117 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type fakeHookStmt struct{ execs int }
+
+func (s *fakeHookStmt) Close() error { return nil }
+func (s *fakeHookStmt) Exec(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	s.execs++
+	return nil, nil
+}
+func (s *fakeHookStmt) Query(ctx context.Context, args ...interface{}) (Rows, error) { return nil, nil }
+func (s *fakeHookStmt) QueryRow(ctx context.Context, args ...interface{}) Row        { return nil }
+func (s *fakeHookStmt) Unwrap() *sql.Stmt                                            { return nil }
+
+type fakeHookTx struct{ stmt Stmt }
+
+func (tx *fakeHookTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+func (tx *fakeHookTx) Prepare(ctx context.Context, query string) (Stmt, error) { return nil, nil }
+func (tx *fakeHookTx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return nil, nil
+}
+func (tx *fakeHookTx) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+func (tx *fakeHookTx) Commit() error                            { return nil }
+func (tx *fakeHookTx) Rollback() error                          { return nil }
+func (tx *fakeHookTx) Stmt(ctx context.Context, stmt Stmt) Stmt { return tx.stmt }
+
+func TestHookedTxStmtRunsHooks(t *testing.T) {
+	var calls []string
+	hooks := Hooks{
+		Before: func(ctx context.Context, op, query string, args []interface{}) context.Context {
+			calls = append(calls, "before:"+op)
+			return ctx
+		},
+		After: func(ctx context.Context, op, query string, args []interface{}, dur time.Duration, err error) {
+			calls = append(calls, "after:"+op)
+		},
+	}
+
+	inner := &fakeHookStmt{}
+	tx := hookedTx{tx: &fakeHookTx{stmt: inner}, hooks: hooks}
+
+	stmt := tx.Stmt(context.Background(), inner)
+	if _, err := stmt.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"before:Exec", "after:Exec"}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("calls = %v, want %v (Tx.Stmt must return a hooked Stmt)", calls, want)
+	}
+	if inner.execs != 1 {
+		t.Errorf("inner.execs = %d, want 1", inner.execs)
+	}
+}
+
+type fakeSpan struct {
+	ended bool
+	err   error
+}
+
+func (s *fakeSpan) End()                  { s.ended = true }
+func (s *fakeSpan) RecordError(err error) { s.err = err }
+
+type fakeTracer struct{ spans []*fakeSpan }
+
+func (tr *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &fakeSpan{}
+	tr.spans = append(tr.spans, span)
+	return ctx, span
+}
+
+func TestTracingHooks(t *testing.T) {
+	tracer := &fakeTracer{}
+	hooks := TracingHooks(tracer)
+
+	ctx := hooks.before(context.Background(), "Exec", "SELECT 1", nil)
+	hooks.after(ctx, "Exec", "SELECT 1", nil, time.Now(), nil)
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(tracer.spans))
+	}
+	if !tracer.spans[0].ended {
+		t.Error("span was not ended")
+	}
+	if tracer.spans[0].err != nil {
+		t.Errorf("span.err = %v, want nil", tracer.spans[0].err)
+	}
+}
+
+type fakeHistogram struct{ observed []float64 }
+
+func (h *fakeHistogram) Observe(v float64) { h.observed = append(h.observed, v) }
+
+func TestMetricsHooks(t *testing.T) {
+	histogram := &fakeHistogram{}
+	hooks := MetricsHooks(histogram)
+
+	ctx := hooks.before(context.Background(), "Query", "SELECT 1", nil)
+	hooks.after(ctx, "Query", "SELECT 1", nil, time.Now().Add(-5*time.Millisecond), nil)
+
+	if len(histogram.observed) != 1 {
+		t.Fatalf("observed = %d, want 1", len(histogram.observed))
+	}
+	if histogram.observed[0] <= 0 {
+		t.Errorf("observed[0] = %v, want > 0", histogram.observed[0])
+	}
+}
@@ -0,0 +1,200 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect identifies the positional placeholder syntax a driver expects.
+type Dialect int
+
+const (
+	// DialectQuestion uses "?" for every placeholder (MySQL, SQLite).
+	DialectQuestion Dialect = iota
+	// DialectDollar uses "$1", "$2", ... (PostgreSQL).
+	DialectDollar
+	// DialectAt uses "@p1", "@p2", ... (SQL Server).
+	DialectAt
+	// DialectColon uses ":1", ":2", ... (Oracle).
+	DialectColon
+)
+
+// Rebind rewrites a query written with "?" placeholders into the
+// positional syntax dialect expects, leaving "?" inside quoted string
+// literals untouched.
+func Rebind(dialect Dialect, query string) string {
+	if dialect == DialectQuestion {
+		return query
+	}
+
+	var out strings.Builder
+	n := 0
+	inString := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inString = !inString
+			out.WriteByte(c)
+		case c == '?' && !inString:
+			n++
+			out.WriteString(placeholder(dialect, n))
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+func placeholder(dialect Dialect, n int) string {
+	switch dialect {
+	case DialectDollar:
+		return "$" + strconv.Itoa(n)
+	case DialectAt:
+		return "@p" + strconv.Itoa(n)
+	case DialectColon:
+		return ":" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// NamedQueryer extends Queryer with sqlx-style named-parameter queries,
+// expanding ":name" placeholders in the query against a map[string]interface{}
+// or a struct whose fields are tagged `db:"name"`.
+type NamedQueryer interface {
+	NamedExec(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	NamedQuery(ctx context.Context, query string, arg interface{}) (Rows, error)
+	NamedQueryRow(ctx context.Context, query string, arg interface{}) Row
+}
+
+// WithDialect wraps q so it also satisfies NamedQueryer, rebinding the "?"
+// placeholders produced from named parameters into dialect's positional
+// syntax before running the query.
+func WithDialect(q Queryer, dialect Dialect) interface {
+	Queryer
+	NamedQueryer
+} {
+	return namedQueryer{q, dialect}
+}
+
+type namedQueryer struct {
+	Queryer
+	dialect Dialect
+}
+
+func (q namedQueryer) NamedExec(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	query, args, err := bindNamed(q.dialect, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.Exec(ctx, query, args...)
+}
+
+func (q namedQueryer) NamedQuery(ctx context.Context, query string, arg interface{}) (Rows, error) {
+	query, args, err := bindNamed(q.dialect, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.Query(ctx, query, args...)
+}
+
+func (q namedQueryer) NamedQueryRow(ctx context.Context, query string, arg interface{}) Row {
+	query, args, err := bindNamed(q.dialect, query, arg)
+	if err != nil {
+		return errRow{err}
+	}
+	return q.QueryRow(ctx, query, args...)
+}
+
+// bindNamed expands ":name" placeholders in query into dialect's
+// positional syntax, returning the rewritten query and the corresponding
+// argument slice, pulled from arg in the order the names appear.
+func bindNamed(dialect Dialect, query string, arg interface{}) (string, []interface{}, error) {
+	values, err := namedValues(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var out strings.Builder
+	var args []interface{}
+	n := 0
+	inString := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inString = !inString
+			out.WriteByte(c)
+		case c == ':' && !inString && i+1 < len(query) && query[i+1] == ':':
+			// "::" is a Postgres type cast (foo::text), not a named
+			// parameter; pass both colons through untouched.
+			out.WriteByte(c)
+			out.WriteByte(query[i+1])
+			i++
+		case c == ':' && !inString && i+1 < len(query) && isNameStart(query[i+1]):
+			j := i + 1
+			for j < len(query) && isNameByte(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			v, ok := values[name]
+			if !ok {
+				return "", nil, fmt.Errorf("sqler: no value for named parameter :%s", name)
+			}
+			n++
+			out.WriteString(placeholder(dialect, n))
+			args = append(args, v)
+			i = j - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String(), args, nil
+}
+
+func isNameStart(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isNameByte(b byte) bool {
+	return isNameStart(b) || (b >= '0' && b <= '9')
+}
+
+// namedValues extracts a name->value map from a map[string]interface{} or a
+// struct (or pointer to struct) whose exported fields are matched by their
+// `db` tag, falling back to the lowercased field name.
+func namedValues(arg interface{}) (map[string]interface{}, error) {
+	if m, ok := arg.(map[string]interface{}); ok {
+		return m, nil
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqler: named argument must be a map[string]interface{} or a struct, got %T", arg)
+	}
+
+	values := make(map[string]interface{}, v.NumField())
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("db")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		} else if name == "-" {
+			continue
+		}
+		values[name] = v.Field(i).Interface()
+	}
+	return values, nil
+}
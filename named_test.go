@@ -0,0 +1,75 @@
+package sqler
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{DialectQuestion, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = ? AND b = ?"},
+		{DialectDollar, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{DialectAt, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = @p1 AND b = @p2"},
+		{DialectColon, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = :1 AND b = :2"},
+		{DialectDollar, "SELECT '?' FROM t WHERE a = ?", "SELECT '?' FROM t WHERE a = $1"},
+	}
+	for _, tt := range tests {
+		if got := Rebind(tt.dialect, tt.query); got != tt.want {
+			t.Errorf("Rebind(%v, %q) = %q, want %q", tt.dialect, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestBindNamedWithMap(t *testing.T) {
+	query, args, err := bindNamed(DialectDollar, "SELECT * FROM t WHERE id = :id AND name = :name", map[string]interface{}{
+		"id":   1,
+		"name": "alice",
+	})
+	if err != nil {
+		t.Fatalf("bindNamed: %v", err)
+	}
+	if want := "SELECT * FROM t WHERE id = $1 AND name = $2"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 2 || args[0] != 1 || args[1] != "alice" {
+		t.Errorf("args = %v, want [1 alice]", args)
+	}
+}
+
+func TestBindNamedWithStruct(t *testing.T) {
+	type arg struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	query, args, err := bindNamed(DialectQuestion, "SELECT * FROM t WHERE id = :id AND name = :name", arg{ID: 1, Name: "alice"})
+	if err != nil {
+		t.Fatalf("bindNamed: %v", err)
+	}
+	if want := "SELECT * FROM t WHERE id = ? AND name = ?"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 2 || args[0] != 1 || args[1] != "alice" {
+		t.Errorf("args = %v, want [1 alice]", args)
+	}
+}
+
+func TestBindNamedMissingValue(t *testing.T) {
+	_, _, err := bindNamed(DialectQuestion, "SELECT * FROM t WHERE id = :id", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected an error for a missing named parameter")
+	}
+}
+
+func TestBindNamedSkipsTypeCasts(t *testing.T) {
+	query, args, err := bindNamed(DialectDollar, "SELECT foo::text FROM t WHERE id = :id", map[string]interface{}{"id": 1})
+	if err != nil {
+		t.Fatalf("bindNamed: %v", err)
+	}
+	if want := "SELECT foo::text FROM t WHERE id = $1"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 1 || args[0] != 1 {
+		t.Errorf("args = %v, want [1]", args)
+	}
+}
@@ -0,0 +1,303 @@
+package sqler
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// CacheOption configures a PreparedStmtDB returned by WrapDBWithStmtCache.
+type CacheOption func(*PreparedStmtDB)
+
+// WithMaxCachedStmts bounds the number of prepared statements kept alive
+// at once. When the bound is exceeded, the least recently used statements
+// that no Prepare handle still holds are closed and evicted to make room;
+// statements with open handles are kept, so the cache can stay above the
+// bound until they are closed. A size of 0, the default, means no bound.
+func WithMaxCachedStmts(n int) CacheOption {
+	return func(db *PreparedStmtDB) {
+		db.maxSize = n
+	}
+}
+
+// PreparedStmtDB is a DB that transparently memoizes Prepare by SQL text,
+// so repeated Exec/Query/QueryRow calls with the same query reuse the same
+// *sql.Stmt instead of preparing it again. It is safe for concurrent use.
+// Every cache access, lookups included, takes the same exclusive lock,
+// since a lookup also moves its entry to the front of the LRU list.
+//
+// The Stmt returned by the public Prepare is reference-counted: each call
+// hands back a handle to the same cached entry, and closing a handle only
+// closes and evicts the underlying *sql.Stmt once every other caller that
+// Prepared the same query has also closed theirs. That keeps one caller's
+// defer stmt.Close() from yanking the statement out from under unrelated
+// concurrent Exec/Query/QueryRow/Prepare calls for the same query text.
+// Close drains the whole cache when the PreparedStmtDB itself is closed.
+type PreparedStmtDB struct {
+	db DB
+
+	mu      sync.Mutex
+	cache   map[string]*list.Element
+	lru     *list.List
+	maxSize int
+}
+
+type cacheEntry struct {
+	query string
+	stmt  Stmt
+	// refCount counts outstanding Stmt handles returned by the public
+	// Prepare, not uses made internally by Exec/Query/QueryRow. While
+	// it's above zero, evictLocked leaves the entry alone even past
+	// maxSize, since closing it would break a caller still holding it.
+	refCount int
+}
+
+// WrapDBWithStmtCache wraps db so that Prepare, and the Exec/Query/QueryRow
+// calls made directly against it, reuse a cached *sql.Stmt keyed by SQL
+// text instead of preparing the query on every call.
+func WrapDBWithStmtCache(db DB, opts ...CacheOption) *PreparedStmtDB {
+	cached := &PreparedStmtDB{
+		db:    db,
+		cache: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+	for _, opt := range opts {
+		opt(cached)
+	}
+	return cached
+}
+
+// lookupOrPrepareLocked returns the cache entry for query, preparing and
+// inserting it if necessary, and evicts down to maxSize afterwards. db.mu
+// must be held.
+func (db *PreparedStmtDB) lookupOrPrepareLocked(ctx context.Context, query string) (*cacheEntry, error) {
+	if elem, ok := db.cache[query]; ok {
+		db.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry), nil
+	}
+
+	stmt, err := db.db.Prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	entry := &cacheEntry{query: query, stmt: stmt}
+	elem := db.lru.PushFront(entry)
+	db.cache[query] = elem
+	db.evictLocked()
+	return entry, nil
+}
+
+// prepareCached returns the cached Stmt for query, preparing and caching
+// it if necessary. It's used internally by Exec/Query/QueryRow, which only
+// ever use the Stmt for the duration of the call, so it doesn't take a
+// reference the way the public Prepare does.
+func (db *PreparedStmtDB) prepareCached(ctx context.Context, query string) (Stmt, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	entry, err := db.lookupOrPrepareLocked(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return entry.stmt, nil
+}
+
+// evictLocked closes and removes least-recently-used statements, skipping
+// any with outstanding Prepare references, until the cache is back within
+// maxSize or nothing more can be evicted. db.mu must be held.
+func (db *PreparedStmtDB) evictLocked() {
+	if db.maxSize <= 0 {
+		return
+	}
+	for elem := db.lru.Back(); elem != nil && db.lru.Len() > db.maxSize; {
+		prev := elem.Prev()
+		if elem.Value.(*cacheEntry).refCount <= 0 {
+			db.removeLocked(elem)
+		}
+		elem = prev
+	}
+}
+
+func (db *PreparedStmtDB) removeLocked(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	db.lru.Remove(elem)
+	delete(db.cache, entry.query)
+	entry.stmt.Close()
+}
+
+func (db *PreparedStmtDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := db.prepareCached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Exec(ctx, args...)
+}
+
+func (db *PreparedStmtDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	stmt, err := db.prepareCached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Query(ctx, args...)
+}
+
+func (db *PreparedStmtDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	stmt, err := db.prepareCached(ctx, query)
+	if err != nil {
+		return errRow{err}
+	}
+	return stmt.QueryRow(ctx, args...)
+}
+
+// Prepare returns a reference-counted handle to the cached Stmt for query,
+// preparing it if this is the first time it's seen. Closing the returned
+// handle only evicts the entry and closes the underlying *sql.Stmt once
+// every other caller that's Prepared the same query text has also closed
+// theirs; until then the statement stays cached and usable by them, and by
+// Exec/Query/QueryRow.
+func (db *PreparedStmtDB) Prepare(ctx context.Context, query string) (Stmt, error) {
+	db.mu.Lock()
+	entry, err := db.lookupOrPrepareLocked(ctx, query)
+	if err != nil {
+		db.mu.Unlock()
+		return nil, err
+	}
+	entry.refCount++
+	db.mu.Unlock()
+	return cachedStmt{entry.stmt, db, entry}, nil
+}
+
+// cachedStmt is the handle PreparedStmtDB.Prepare hands back to callers.
+// It identifies its cache entry by pointer, not by query text, so closing
+// a stale handle can never evict a newer entry that happens to share the
+// same SQL text after an eviction and re-prepare.
+type cachedStmt struct {
+	Stmt
+	db    *PreparedStmtDB
+	entry *cacheEntry
+}
+
+func (s cachedStmt) Close() error {
+	s.db.mu.Lock()
+	s.entry.refCount--
+	evict := s.entry.refCount <= 0
+	if evict {
+		if elem, ok := s.db.cache[s.entry.query]; ok && elem.Value.(*cacheEntry) == s.entry {
+			s.db.lru.Remove(elem)
+			delete(s.db.cache, s.entry.query)
+		}
+	}
+	s.db.mu.Unlock()
+	if evict {
+		return s.entry.stmt.Close()
+	}
+	return nil
+}
+
+// BeginTx starts a transaction whose Exec/Query/QueryRow/Prepare calls
+// still go through the statement cache: each rebinds the cached Stmt into
+// the new Tx via Tx.Stmt instead of preparing a fresh one.
+func (db *PreparedStmtDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cachedTx{db, tx}, nil
+}
+
+// cachedTx is the Tx returned by PreparedStmtDB.BeginTx. It looks up or
+// prepares each query against the shared statement cache and binds the
+// result into the transaction with Tx.Stmt, so a query already cached on
+// the DB doesn't need to be prepared again inside the transaction.
+type cachedTx struct {
+	db *PreparedStmtDB
+	tx Tx
+}
+
+var _ Tx = cachedTx{}
+
+func (tx cachedTx) stmt(ctx context.Context, query string) (Stmt, error) {
+	cached, err := tx.db.prepareCached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.tx.Stmt(ctx, cached), nil
+}
+
+func (tx cachedTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := tx.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Exec(ctx, args...)
+}
+
+func (tx cachedTx) Prepare(ctx context.Context, query string) (Stmt, error) {
+	return tx.stmt(ctx, query)
+}
+
+func (tx cachedTx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	stmt, err := tx.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Query(ctx, args...)
+}
+
+func (tx cachedTx) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	stmt, err := tx.stmt(ctx, query)
+	if err != nil {
+		return errRow{err}
+	}
+	return stmt.QueryRow(ctx, args...)
+}
+
+func (tx cachedTx) Commit() error   { return tx.tx.Commit() }
+func (tx cachedTx) Rollback() error { return tx.tx.Rollback() }
+
+func (tx cachedTx) Stmt(ctx context.Context, stmt Stmt) Stmt {
+	return tx.tx.Stmt(ctx, stmt)
+}
+
+func (db *PreparedStmtDB) Conn(ctx context.Context) (Conn, error) {
+	return db.db.Conn(ctx)
+}
+
+func (db *PreparedStmtDB) Ping(ctx context.Context) error {
+	return db.db.Ping(ctx)
+}
+
+// Close closes every cached statement, draining the cache, and then closes
+// the underlying DB.
+func (db *PreparedStmtDB) Close() error {
+	db.mu.Lock()
+	for _, elem := range db.cache {
+		elem.Value.(*cacheEntry).stmt.Close()
+	}
+	db.cache = make(map[string]*list.Element)
+	db.lru.Init()
+	db.mu.Unlock()
+	return db.db.Close()
+}
+
+func (db *PreparedStmtDB) SetConnMaxLifetime(d time.Duration) {
+	db.db.SetConnMaxLifetime(d)
+}
+
+func (db *PreparedStmtDB) SetMaxIdleConns(n int) {
+	db.db.SetMaxIdleConns(n)
+}
+
+func (db *PreparedStmtDB) SetMaxOpenConns(n int) {
+	db.db.SetMaxOpenConns(n)
+}
+
+func (db *PreparedStmtDB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
+
+func (db *PreparedStmtDB) Unwrap() *sql.DB {
+	return db.db.Unwrap()
+}
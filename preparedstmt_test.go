@@ -0,0 +1,209 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type fakeStmt struct {
+	query  string
+	closed bool
+}
+
+func (s *fakeStmt) Close() error { s.closed = true; return nil }
+func (s *fakeStmt) Exec(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+func (s *fakeStmt) Query(ctx context.Context, args ...interface{}) (Rows, error) { return nil, nil }
+func (s *fakeStmt) QueryRow(ctx context.Context, args ...interface{}) Row        { return nil }
+func (s *fakeStmt) Unwrap() *sql.Stmt                                            { return nil }
+
+// fakePrepareDB is a DB whose only interesting behavior is counting how
+// many times Prepare is called per query, so tests can assert caching.
+type fakePrepareDB struct {
+	prepareCalls map[string]int
+}
+
+func newFakePrepareDB() *fakePrepareDB {
+	return &fakePrepareDB{prepareCalls: make(map[string]int)}
+}
+
+func (db *fakePrepareDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+func (db *fakePrepareDB) Prepare(ctx context.Context, query string) (Stmt, error) {
+	db.prepareCalls[query]++
+	return &fakeStmt{query: query}, nil
+}
+func (db *fakePrepareDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return nil, nil
+}
+func (db *fakePrepareDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+func (db *fakePrepareDB) Close() error { return nil }
+func (db *fakePrepareDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return &fakePrepareTx{}, nil
+}
+func (db *fakePrepareDB) Ping(ctx context.Context) error         { return nil }
+func (db *fakePrepareDB) Conn(ctx context.Context) (Conn, error) { return nil, nil }
+func (db *fakePrepareDB) SetConnMaxLifetime(d time.Duration)     {}
+func (db *fakePrepareDB) SetMaxIdleConns(n int)                  {}
+func (db *fakePrepareDB) SetMaxOpenConns(n int)                  {}
+func (db *fakePrepareDB) Stats() sql.DBStats                     { return sql.DBStats{} }
+func (db *fakePrepareDB) Unwrap() *sql.DB                        { return nil }
+
+var _ DB = (*fakePrepareDB)(nil)
+
+// fakePrepareTx is a Tx whose Stmt just records how many times it was
+// asked to rebind a Stmt into the transaction.
+type fakePrepareTx struct {
+	stmtCalls int
+}
+
+func (tx *fakePrepareTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+func (tx *fakePrepareTx) Prepare(ctx context.Context, query string) (Stmt, error) { return nil, nil }
+func (tx *fakePrepareTx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return nil, nil
+}
+func (tx *fakePrepareTx) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+func (tx *fakePrepareTx) Commit() error   { return nil }
+func (tx *fakePrepareTx) Rollback() error { return nil }
+func (tx *fakePrepareTx) Stmt(ctx context.Context, stmt Stmt) Stmt {
+	tx.stmtCalls++
+	return stmt
+}
+
+func TestPreparedStmtDBCachesByQuery(t *testing.T) {
+	fake := newFakePrepareDB()
+	cached := WrapDBWithStmtCache(fake)
+
+	ctx := context.Background()
+	if _, err := cached.Exec(ctx, "INSERT INTO t VALUES (?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Exec(ctx, "INSERT INTO t VALUES (?)", 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Query(ctx, "SELECT * FROM t"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fake.prepareCalls["INSERT INTO t VALUES (?)"]; got != 1 {
+		t.Errorf("prepareCalls[insert] = %d, want 1", got)
+	}
+	if got := fake.prepareCalls["SELECT * FROM t"]; got != 1 {
+		t.Errorf("prepareCalls[select] = %d, want 1", got)
+	}
+}
+
+func TestPreparedStmtDBEvictsOnMaxSize(t *testing.T) {
+	fake := newFakePrepareDB()
+	cached := WrapDBWithStmtCache(fake, WithMaxCachedStmts(1))
+
+	ctx := context.Background()
+	cached.Exec(ctx, "A")
+	cached.Exec(ctx, "B")
+	cached.Exec(ctx, "A")
+
+	if got := fake.prepareCalls["A"]; got != 2 {
+		t.Errorf("prepareCalls[A] = %d, want 2 (evicted once B was prepared)", got)
+	}
+}
+
+func TestPreparedStmtClosingEvictsFromCache(t *testing.T) {
+	fake := newFakePrepareDB()
+	cached := WrapDBWithStmtCache(fake)
+
+	ctx := context.Background()
+	stmt, err := cached.Prepare(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cached.Prepare(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.prepareCalls["SELECT 1"]; got != 2 {
+		t.Errorf("prepareCalls = %d, want 2 (cache entry should have been evicted on Close)", got)
+	}
+}
+
+func TestPreparedStmtClosingOneHandleDoesNotBreakAnother(t *testing.T) {
+	fake := newFakePrepareDB()
+	cached := WrapDBWithStmtCache(fake)
+	ctx := context.Background()
+
+	a, err := cached.Prepare(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cached.Prepare(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.prepareCalls["SELECT 1"]; got != 1 {
+		t.Fatalf("prepareCalls = %d, want 1 (both Prepare calls should share one statement)", got)
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.prepareCalls["SELECT 1"]; got != 1 {
+		t.Errorf("prepareCalls = %d, want 1 (closing one handle must not evict it while b is still open)", got)
+	}
+	if underlying := b.(cachedStmt).entry.stmt.(*fakeStmt); underlying.closed {
+		t.Error("underlying stmt was closed while handle b is still open")
+	}
+
+	if _, err := cached.Exec(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.prepareCalls["SELECT 1"]; got != 1 {
+		t.Errorf("prepareCalls = %d, want 1 (Exec should still hit the cache)", got)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Prepare(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.prepareCalls["SELECT 1"]; got != 2 {
+		t.Errorf("prepareCalls = %d, want 2 (evicted once the last handle was closed)", got)
+	}
+}
+
+func TestPreparedStmtDBBeginTxRebindsCachedStmt(t *testing.T) {
+	fake := newFakePrepareDB()
+	cached := WrapDBWithStmtCache(fake)
+	ctx := context.Background()
+
+	if _, err := cached.Exec(ctx, "INSERT INTO t VALUES (?)", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := cached.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO t VALUES (?)", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fake.prepareCalls["INSERT INTO t VALUES (?)"]; got != 1 {
+		t.Errorf("prepareCalls = %d, want 1 (Tx should reuse the cached Stmt, not re-prepare)", got)
+	}
+	if got := tx.(cachedTx).tx.(*fakePrepareTx).stmtCalls; got != 1 {
+		t.Errorf("stmtCalls = %d, want 1 (Tx.Exec should rebind the cached Stmt via Tx.Stmt)", got)
+	}
+}
@@ -0,0 +1,179 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Classifier reports whether query should be routed to the primary (true)
+// rather than load-balanced across replicas.
+type Classifier func(query string) bool
+
+// Selector picks the index, in [0, n), of the replica that should serve the
+// next read. It is called with the current number of replicas.
+type Selector func(n int) int
+
+// Resolver is a DB that splits reads and writes across a primary and a set
+// of read replicas. Exec, Prepare of non-SELECT statements, DDL and
+// BeginTx are routed to the primary; Query and QueryRow of SELECT
+// statements are routed to a replica chosen by Selector, or to the primary
+// if there are none. Classifier decides what counts as a SELECT here.
+//
+// Once a Tx or Conn has been obtained, every call made through it goes to
+// the same underlying connection, so callers get the usual sticky-session
+// guarantees inside a transaction.
+type Resolver struct {
+	// Classifier decides, for Prepare/Query/QueryRow, whether a query
+	// must go to the primary. It defaults to treating anything that
+	// isn't a SELECT as a write, and can be replaced to override that
+	// heuristic, e.g. to route SELECT ... FOR UPDATE to the primary too.
+	Classifier Classifier
+
+	// Selector picks which replica serves the next read. It defaults to
+	// round-robin and can be replaced, e.g. with random selection.
+	Selector Selector
+
+	primary  DB
+	replicas []DB
+	counter  uint64
+}
+
+var _ DB = (*Resolver)(nil)
+
+// NewResolver returns a Resolver that writes to primary and balances reads
+// across replicas. With no replicas, every call goes to primary. Its
+// Classifier and Selector fields can be set to override the default
+// routing heuristic and replica selection strategy.
+func NewResolver(primary DB, replicas ...DB) *Resolver {
+	r := &Resolver{
+		primary:    primary,
+		replicas:   replicas,
+		Classifier: defaultClassifier,
+	}
+	r.Selector = r.roundRobin
+	return r
+}
+
+// defaultClassifier treats anything that isn't a SELECT as a write.
+func defaultClassifier(query string) bool {
+	q := strings.TrimSpace(query)
+	i := strings.IndexFunc(q, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' })
+	if i >= 0 {
+		q = q[:i]
+	}
+	return !strings.EqualFold(q, "select")
+}
+
+func (r *Resolver) roundRobin(n int) int {
+	return int(atomic.AddUint64(&r.counter, 1) % uint64(n))
+}
+
+// replicaFor returns the replica that should serve a read, or primary if
+// there are no replicas.
+func (r *Resolver) replicaFor() DB {
+	if len(r.replicas) == 0 {
+		return r.primary
+	}
+	return r.replicas[r.Selector(len(r.replicas))]
+}
+
+// targetFor routes query to primary or a replica according to Classifier.
+func (r *Resolver) targetFor(query string) DB {
+	if r.Classifier(query) {
+		return r.primary
+	}
+	return r.replicaFor()
+}
+
+func (r *Resolver) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.Exec(ctx, query, args...)
+}
+
+func (r *Resolver) Prepare(ctx context.Context, query string) (Stmt, error) {
+	return r.targetFor(query).Prepare(ctx, query)
+}
+
+func (r *Resolver) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return r.targetFor(query).Query(ctx, query, args...)
+}
+
+func (r *Resolver) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return r.targetFor(query).QueryRow(ctx, query, args...)
+}
+
+// BeginTx always starts the transaction on the primary, so every statement
+// run through the returned Tx is sticky to it.
+func (r *Resolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// Conn returns a Conn pinned to the primary, so reads and writes made
+// through it are sticky to the same underlying connection.
+func (r *Resolver) Conn(ctx context.Context) (Conn, error) {
+	return r.primary.Conn(ctx)
+}
+
+func (r *Resolver) Ping(ctx context.Context) error {
+	return r.primary.Ping(ctx)
+}
+
+func (r *Resolver) Close() error {
+	err := r.primary.Close()
+	for _, replica := range r.replicas {
+		if rerr := replica.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+func (r *Resolver) SetConnMaxLifetime(d time.Duration) {
+	r.primary.SetConnMaxLifetime(d)
+	for _, replica := range r.replicas {
+		replica.SetConnMaxLifetime(d)
+	}
+}
+
+func (r *Resolver) SetMaxIdleConns(n int) {
+	r.primary.SetMaxIdleConns(n)
+	for _, replica := range r.replicas {
+		replica.SetMaxIdleConns(n)
+	}
+}
+
+func (r *Resolver) SetMaxOpenConns(n int) {
+	r.primary.SetMaxOpenConns(n)
+	for _, replica := range r.replicas {
+		replica.SetMaxOpenConns(n)
+	}
+}
+
+// Stats returns the primary's stats, so a Resolver can be used wherever a
+// DB is expected. Use TargetStats for a per-target breakdown.
+func (r *Resolver) Stats() sql.DBStats {
+	return r.primary.Stats()
+}
+
+// TargetStats returns the stats of the primary and of every replica,
+// keyed "primary", "replica0", "replica1", etc.
+func (r *Resolver) TargetStats() map[string]sql.DBStats {
+	stats := make(map[string]sql.DBStats, len(r.replicas)+1)
+	stats["primary"] = r.primary.Stats()
+	for i, replica := range r.replicas {
+		stats[replicaKey(i)] = replica.Stats()
+	}
+	return stats
+}
+
+// Unwrap returns the primary's underlying *sql.DB.
+func (r *Resolver) Unwrap() *sql.DB {
+	return r.primary.Unwrap()
+}
+
+func replicaKey(i int) string {
+	return "replica" + strconv.Itoa(i)
+}
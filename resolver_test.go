@@ -0,0 +1,118 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type fakeResolverDB struct {
+	name  string
+	execs int
+}
+
+func (db *fakeResolverDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.execs++
+	return nil, nil
+}
+func (db *fakeResolverDB) Prepare(ctx context.Context, query string) (Stmt, error) { return nil, nil }
+func (db *fakeResolverDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return &fakeRows{columns: []string{"target"}, data: [][]interface{}{{db.name}}}, nil
+}
+func (db *fakeResolverDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+func (db *fakeResolverDB) Close() error { return nil }
+func (db *fakeResolverDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return nil, nil
+}
+func (db *fakeResolverDB) Ping(ctx context.Context) error         { return nil }
+func (db *fakeResolverDB) Conn(ctx context.Context) (Conn, error) { return nil, nil }
+func (db *fakeResolverDB) SetConnMaxLifetime(d time.Duration)     {}
+func (db *fakeResolverDB) SetMaxIdleConns(n int)                  {}
+func (db *fakeResolverDB) SetMaxOpenConns(n int)                  {}
+func (db *fakeResolverDB) Stats() sql.DBStats                     { return sql.DBStats{} }
+func (db *fakeResolverDB) Unwrap() *sql.DB                        { return nil }
+
+var _ DB = (*fakeResolverDB)(nil)
+
+func queryTarget(t *testing.T, r *Resolver, query string) string {
+	t.Helper()
+	rows, err := r.Query(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr := rows.(*fakeRows)
+	fr.Next()
+	var target string
+	if err := fr.Scan(&target); err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func TestResolverRoutesWritesToPrimary(t *testing.T) {
+	primary := &fakeResolverDB{name: "primary"}
+	replica := &fakeResolverDB{name: "replica"}
+	r := NewResolver(primary, replica)
+
+	if _, err := r.Exec(context.Background(), "INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	if primary.execs != 1 {
+		t.Errorf("primary.execs = %d, want 1", primary.execs)
+	}
+	if replica.execs != 0 {
+		t.Errorf("replica.execs = %d, want 0", replica.execs)
+	}
+}
+
+func TestResolverRoutesSelectsToReplicas(t *testing.T) {
+	primary := &fakeResolverDB{name: "primary"}
+	replicaA := &fakeResolverDB{name: "a"}
+	replicaB := &fakeResolverDB{name: "b"}
+	r := NewResolver(primary, replicaA, replicaB)
+
+	got := map[string]int{}
+	for i := 0; i < 4; i++ {
+		got[queryTarget(t, r, "SELECT * FROM t")]++
+	}
+	if got["a"] != 2 || got["b"] != 2 {
+		t.Errorf("got = %v, want each replica picked twice by round-robin", got)
+	}
+}
+
+func TestResolverCustomClassifierOverridesRouting(t *testing.T) {
+	primary := &fakeResolverDB{name: "primary"}
+	replica := &fakeResolverDB{name: "replica"}
+	r := NewResolver(primary, replica)
+	r.Classifier = func(query string) bool { return true } // route everything to primary
+
+	if got := queryTarget(t, r, "SELECT * FROM t FOR UPDATE"); got != "primary" {
+		t.Errorf("got = %q, want primary with an all-writes Classifier", got)
+	}
+}
+
+func TestResolverWithNoReplicasUsesPrimary(t *testing.T) {
+	primary := &fakeResolverDB{name: "primary"}
+	r := NewResolver(primary)
+
+	if got := queryTarget(t, r, "SELECT * FROM t"); got != "primary" {
+		t.Errorf("got = %q, want primary", got)
+	}
+}
+
+func TestResolverTargetStats(t *testing.T) {
+	primary := &fakeResolverDB{name: "primary"}
+	replica := &fakeResolverDB{name: "replica"}
+	r := NewResolver(primary, replica)
+
+	stats := r.TargetStats()
+	if _, ok := stats["primary"]; !ok {
+		t.Error(`TargetStats()["primary"] missing`)
+	}
+	if _, ok := stats["replica0"]; !ok {
+		t.Error(`TargetStats()["replica0"] missing`)
+	}
+}
@@ -0,0 +1,247 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// IsRetryable reports whether err is transient and worth retrying, e.g. a
+// serialization failure, a deadlock, or a dropped connection.
+type IsRetryable func(error) bool
+
+// DefaultIsRetryable recognizes sql.ErrConnDone and the common driver
+// messages for serialization failures, deadlocks and connection resets.
+// It's a best effort: drivers don't agree on a common error type for these,
+// so it falls back to matching substrings of err.Error().
+func DefaultIsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == sql.ErrConnDone {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"serialization failure",
+		"deadlock",
+		"connection reset",
+		"broken pipe",
+		"bad connection",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryOption configures a RetryDB returned by NewRetryDB.
+type RetryOption func(*RetryDB)
+
+// WithIsRetryable overrides the default transient-error classifier.
+func WithIsRetryable(isRetryable IsRetryable) RetryOption {
+	return func(db *RetryDB) {
+		db.IsRetryable = isRetryable
+	}
+}
+
+// WithMaxAttempts bounds how many times a call is attempted in all, the
+// first attempt included. The default is 3.
+func WithMaxAttempts(n int) RetryOption {
+	return func(db *RetryDB) {
+		db.MaxAttempts = n
+	}
+}
+
+// WithBackoff sets the base and cap of the exponential backoff between
+// attempts. The default is a 10ms base doubling up to a 1s cap.
+func WithBackoff(base, maxDelay time.Duration) RetryOption {
+	return func(db *RetryDB) {
+		db.BaseDelay = base
+		db.MaxDelay = maxDelay
+	}
+}
+
+// RetryDB is a DB that re-runs Exec, Query, QueryRow, Ping and BeginTx when
+// the underlying error is classified as transient by IsRetryable, waiting
+// an exponential backoff between attempts.
+//
+// A RetryDB never retries calls made through a Tx it returned: once a
+// transaction has started, replaying a single statement could re-run it
+// against inconsistent state. Use RunInTx to retry a whole transaction.
+type RetryDB struct {
+	IsRetryable IsRetryable
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	db DB
+}
+
+// NewRetryDB wraps db so transient errors are retried with backoff.
+func NewRetryDB(db DB, opts ...RetryOption) *RetryDB {
+	r := &RetryDB{
+		db:          db,
+		IsRetryable: DefaultIsRetryable,
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// retry calls attempt up to MaxAttempts times, sleeping a backoff between
+// retryable failures, and gives up as soon as ctx is done or the error
+// isn't retryable.
+func (db *RetryDB) retry(ctx context.Context, attempt func() error) error {
+	var err error
+	for i := 0; i < db.MaxAttempts; i++ {
+		if i > 0 {
+			if sleepErr := db.sleep(ctx, i); sleepErr != nil {
+				return sleepErr
+			}
+		}
+		err = attempt()
+		if err == nil || !db.IsRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (db *RetryDB) sleep(ctx context.Context, attempt int) error {
+	delay := db.BaseDelay
+	for i := 1; i < attempt && delay < db.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > db.MaxDelay {
+		delay = db.MaxDelay
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (db *RetryDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := db.retry(ctx, func() error {
+		var err error
+		res, err = db.db.Exec(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+func (db *RetryDB) Prepare(ctx context.Context, query string) (Stmt, error) {
+	var stmt Stmt
+	err := db.retry(ctx, func() error {
+		var err error
+		stmt, err = db.db.Prepare(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+func (db *RetryDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	var rows Rows
+	err := db.retry(ctx, func() error {
+		var err error
+		rows, err = db.db.Query(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRow returns a Row that retries the whole query when Scan is called,
+// since, like *sql.Row, the query doesn't actually run until then: there's
+// no error to classify as retryable until a caller calls Scan.
+func (db *RetryDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return retryRow{db, ctx, query, args}
+}
+
+type retryRow struct {
+	db    *RetryDB
+	ctx   context.Context
+	query string
+	args  []interface{}
+}
+
+func (r retryRow) Scan(dest ...interface{}) error {
+	return r.db.retry(r.ctx, func() error {
+		return r.db.db.QueryRow(r.ctx, r.query, r.args...).Scan(dest...)
+	})
+}
+
+func (db *RetryDB) Ping(ctx context.Context) error {
+	return db.retry(ctx, func() error {
+		return db.db.Ping(ctx)
+	})
+}
+
+// BeginTx retries starting the transaction itself, but the returned Tx is
+// not retried: once open, its calls go straight to the underlying DB.
+func (db *RetryDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	var tx Tx
+	err := db.retry(ctx, func() error {
+		var err error
+		tx, err = db.db.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+func (db *RetryDB) Conn(ctx context.Context) (Conn, error) {
+	return db.db.Conn(ctx)
+}
+
+func (db *RetryDB) Close() error {
+	return db.db.Close()
+}
+
+func (db *RetryDB) SetConnMaxLifetime(d time.Duration) {
+	db.db.SetConnMaxLifetime(d)
+}
+
+func (db *RetryDB) SetMaxIdleConns(n int) {
+	db.db.SetMaxIdleConns(n)
+}
+
+func (db *RetryDB) SetMaxOpenConns(n int) {
+	db.db.SetMaxOpenConns(n)
+}
+
+func (db *RetryDB) Stats() sql.DBStats {
+	return db.db.Stats()
+}
+
+func (db *RetryDB) Unwrap() *sql.DB {
+	return db.db.Unwrap()
+}
+
+// RunInTx runs fn in a transaction started with opts, committing on
+// success and rolling back on error. If fn or the commit fails with a
+// retryable error, the whole transaction is retried from the beginning,
+// modeled on go-pg's RunInTransaction.
+func (db *RetryDB) RunInTx(ctx context.Context, opts *sql.TxOptions, fn func(Tx) error) error {
+	return db.retry(ctx, func() error {
+		tx, err := db.db.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
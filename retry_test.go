@@ -0,0 +1,138 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeRetryDB struct {
+	execErrs     []error
+	execCalls    int
+	queryRowErrs []error
+	queryRowDest func(dest ...interface{})
+	queryRowCall int
+}
+
+func (db *fakeRetryDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	err := db.execErrs[db.execCalls]
+	db.execCalls++
+	return nil, err
+}
+func (db *fakeRetryDB) Prepare(ctx context.Context, query string) (Stmt, error) { return nil, nil }
+func (db *fakeRetryDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return nil, nil
+}
+func (db *fakeRetryDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return fakeRetryRow{db}
+}
+func (db *fakeRetryDB) Close() error { return nil }
+func (db *fakeRetryDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return nil, nil
+}
+func (db *fakeRetryDB) Ping(ctx context.Context) error         { return nil }
+func (db *fakeRetryDB) Conn(ctx context.Context) (Conn, error) { return nil, nil }
+func (db *fakeRetryDB) SetConnMaxLifetime(d time.Duration)     {}
+func (db *fakeRetryDB) SetMaxIdleConns(n int)                  {}
+func (db *fakeRetryDB) SetMaxOpenConns(n int)                  {}
+func (db *fakeRetryDB) Stats() sql.DBStats                     { return sql.DBStats{} }
+func (db *fakeRetryDB) Unwrap() *sql.DB                        { return nil }
+
+var _ DB = (*fakeRetryDB)(nil)
+
+type fakeRetryRow struct{ db *fakeRetryDB }
+
+func (r fakeRetryRow) Scan(dest ...interface{}) error {
+	err := r.db.queryRowErrs[r.db.queryRowCall]
+	r.db.queryRowCall++
+	if err == nil && r.db.queryRowDest != nil {
+		r.db.queryRowDest(dest...)
+	}
+	return err
+}
+
+var errTransient = errors.New("connection reset by peer")
+var errPermanent = errors.New("syntax error")
+
+func newTestRetryDB(fake *fakeRetryDB) *RetryDB {
+	return NewRetryDB(fake, WithBackoff(time.Millisecond, time.Millisecond))
+}
+
+func TestRetryDBExecRetriesTransientErrors(t *testing.T) {
+	fake := &fakeRetryDB{execErrs: []error{errTransient, errTransient, nil}}
+	db := newTestRetryDB(fake)
+
+	if _, err := db.Exec(context.Background(), "INSERT"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if fake.execCalls != 3 {
+		t.Errorf("execCalls = %d, want 3", fake.execCalls)
+	}
+}
+
+func TestRetryDBExecGivesUpOnPermanentError(t *testing.T) {
+	fake := &fakeRetryDB{execErrs: []error{errPermanent, nil, nil}}
+	db := newTestRetryDB(fake)
+
+	_, err := db.Exec(context.Background(), "INSERT")
+	if err != errPermanent {
+		t.Errorf("err = %v, want errPermanent", err)
+	}
+	if fake.execCalls != 1 {
+		t.Errorf("execCalls = %d, want 1 (no retry on a non-retryable error)", fake.execCalls)
+	}
+}
+
+func TestRetryDBExecStopsAtMaxAttempts(t *testing.T) {
+	fake := &fakeRetryDB{execErrs: []error{errTransient, errTransient, errTransient, errTransient}}
+	db := NewRetryDB(fake, WithBackoff(time.Millisecond, time.Millisecond), WithMaxAttempts(2))
+
+	_, err := db.Exec(context.Background(), "INSERT")
+	if err != errTransient {
+		t.Errorf("err = %v, want errTransient", err)
+	}
+	if fake.execCalls != 2 {
+		t.Errorf("execCalls = %d, want 2 (MaxAttempts)", fake.execCalls)
+	}
+}
+
+func TestRetryDBQueryRowRetriesOnScan(t *testing.T) {
+	fake := &fakeRetryDB{queryRowErrs: []error{errTransient, nil}}
+	db := newTestRetryDB(fake)
+
+	var got int
+	fake.queryRowDest = func(dest ...interface{}) {
+		*(dest[0].(*int)) = 42
+	}
+
+	row := db.QueryRow(context.Background(), "SELECT 1")
+	if err := row.Scan(&got); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if got != 42 {
+		t.Errorf("got = %d, want 42", got)
+	}
+	if fake.queryRowCall != 2 {
+		t.Errorf("queryRowCall = %d, want 2 (Scan must retry the whole query)", fake.queryRowCall)
+	}
+}
+
+func TestDefaultIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{sql.ErrNoRows, false},
+		{errors.New("deadlock detected"), true},
+		{errors.New("SQLSTATE 40001 serialization failure"), true},
+		{errors.New("syntax error near SELECT"), false},
+	}
+	for _, c := range cases {
+		if got := DefaultIsRetryable(c.err); got != c.want {
+			t.Errorf("DefaultIsRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
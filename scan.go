@@ -0,0 +1,167 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fieldMapper caches, per struct type, a column name (the `db` tag when
+// present, else the field name, lowercased either way) to field index
+// path, so repeated scans of the
+// same type avoid re-walking its fields with reflection. Index paths have
+// more than one element for fields promoted from an embedded struct.
+var fieldMapper sync.Map // map[reflect.Type]map[string][]int
+
+func fieldsFor(t reflect.Type) map[string][]int {
+	if cached, ok := fieldMapper.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := mapFields(t, nil)
+	fieldMapper.Store(t, fields)
+	return fields
+}
+
+func mapFields(t reflect.Type, index []int) map[string][]int {
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		idx := append(append([]int(nil), index...), i)
+
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for name, embeddedIdx := range mapFields(ft, idx) {
+					if _, exists := fields[name]; !exists {
+						fields[name] = embeddedIdx
+					}
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("db")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = idx
+	}
+	return fields
+}
+
+// StructScan scans the current row of rows into dest, a pointer to a
+// struct whose fields are matched to columns by their `db` tag, falling
+// back to the lowercased field name. It does not call rows.Next(); callers
+// drive the loop themselves, the same as with rows.Scan.
+func StructScan(rows Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqler: StructScan dest must be a pointer to a struct, got %T", dest)
+	}
+	return structScan(rows, v.Elem())
+}
+
+func structScan(rows Rows, structVal reflect.Value) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields := fieldsFor(structVal.Type())
+
+	dests := make([]interface{}, len(columns))
+	for i, col := range columns {
+		idx, ok := fields[strings.ToLower(col)]
+		if !ok {
+			var discard interface{}
+			dests[i] = &discard
+			continue
+		}
+		dests[i] = fieldByIndex(structVal, idx).Addr().Interface()
+	}
+	return rows.Scan(dests...)
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but allocates nil
+// pointers to embedded structs it needs to walk through.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+// SelectAll runs query against q and scans every row into dest, a pointer
+// to a slice of structs (or of pointers to structs).
+func SelectAll(ctx context.Context, q Queryer, dest interface{}, query string, args ...interface{}) error {
+	slicePtr := reflect.ValueOf(dest)
+	if slicePtr.Kind() != reflect.Ptr || slicePtr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sqler: SelectAll dest must be a pointer to a slice, got %T", dest)
+	}
+	sliceVal := slicePtr.Elem()
+	elemType := sliceVal.Type().Elem()
+	elemIsPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if elemIsPtr {
+		structType = elemType.Elem()
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		elem := reflect.New(structType)
+		if err := structScan(rows, elem.Elem()); err != nil {
+			return err
+		}
+		if elemIsPtr {
+			sliceVal.Set(reflect.Append(sliceVal, elem))
+		} else {
+			sliceVal.Set(reflect.Append(sliceVal, elem.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+// Get runs query against q and scans its single result row into dest, a
+// pointer to a struct. It returns sql.ErrNoRows if the query had no rows.
+func Get(ctx context.Context, q Queryer, dest interface{}, query string, args ...interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqler: Get dest must be a pointer to a struct, got %T", dest)
+	}
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return structScan(rows, v.Elem())
+}
@@ -0,0 +1,133 @@
+package sqler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// fakeRows is a minimal in-memory Rows backed by a slice of column values,
+// just enough to exercise StructScan/SelectAll/Get without a real driver.
+type fakeRows struct {
+	columns []string
+	data    [][]interface{}
+	pos     int
+}
+
+func (r *fakeRows) Close() error                            { return nil }
+func (r *fakeRows) ColumnTypes() ([]*sql.ColumnType, error) { return nil, nil }
+func (r *fakeRows) Columns() ([]string, error)              { return r.columns, nil }
+func (r *fakeRows) Err() error                              { return nil }
+func (r *fakeRows) NextResultSet() bool                     { return false }
+func (r *fakeRows) Next() bool {
+	if r.pos >= len(r.data) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	row := r.data[r.pos-1]
+	for i, d := range dest {
+		switch v := d.(type) {
+		case *int:
+			*v = row[i].(int)
+		case *string:
+			*v = row[i].(string)
+		case *interface{}:
+			*v = row[i]
+		default:
+			panic("fakeRows.Scan: unsupported dest type")
+		}
+	}
+	return nil
+}
+
+type fakeQueryer struct {
+	rows *fakeRows
+}
+
+func (q fakeQueryer) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+func (q fakeQueryer) Prepare(ctx context.Context, query string) (Stmt, error) { return nil, nil }
+func (q fakeQueryer) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return q.rows, nil
+}
+func (q fakeQueryer) QueryRow(ctx context.Context, query string, args ...interface{}) Row { return nil }
+
+func TestStructScanUppercaseTag(t *testing.T) {
+	type user struct {
+		ID   int    `db:"UserID"`
+		Name string `db:"Name"`
+	}
+	rows := &fakeRows{
+		columns: []string{"userid", "name"},
+		data:    [][]interface{}{{1, "alice"}},
+	}
+	rows.Next()
+
+	var u user
+	if err := StructScan(rows, &u); err != nil {
+		t.Fatalf("StructScan: %v", err)
+	}
+	if u.ID != 1 || u.Name != "alice" {
+		t.Errorf("u = %+v, want {1 alice}", u)
+	}
+}
+
+func TestStructScanEmbedded(t *testing.T) {
+	type base struct {
+		ID int `db:"id"`
+	}
+	type user struct {
+		base
+		Name string `db:"name"`
+	}
+	rows := &fakeRows{
+		columns: []string{"id", "name"},
+		data:    [][]interface{}{{1, "alice"}},
+	}
+	rows.Next()
+
+	var u user
+	if err := StructScan(rows, &u); err != nil {
+		t.Fatalf("StructScan: %v", err)
+	}
+	if u.ID != 1 || u.Name != "alice" {
+		t.Errorf("u = %+v, want {1 alice}", u)
+	}
+}
+
+func TestSelectAll(t *testing.T) {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	q := fakeQueryer{rows: &fakeRows{
+		columns: []string{"id", "name"},
+		data:    [][]interface{}{{1, "alice"}, {2, "bob"}},
+	}}
+
+	var users []user
+	if err := SelectAll(context.Background(), q, &users, "SELECT id, name FROM users"); err != nil {
+		t.Fatalf("SelectAll: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "alice" || users[1].Name != "bob" {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+func TestGetNoRows(t *testing.T) {
+	type user struct {
+		ID int `db:"id"`
+	}
+	q := fakeQueryer{rows: &fakeRows{columns: []string{"id"}}}
+
+	var u user
+	err := Get(context.Background(), q, &u, "SELECT id FROM users WHERE id = ?", 1)
+	if err != sql.ErrNoRows {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+}
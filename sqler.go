@@ -26,6 +26,9 @@ type Queryer interface {
 
 type DB interface {
 	Conn
+	// Conn returns a single connection, pinned so that every call made
+	// through it is sticky to the same underlying connection.
+	Conn(ctx context.Context) (Conn, error)
 	SetConnMaxLifetime(d time.Duration)
 	SetMaxIdleConns(n int)
 	SetMaxOpenConns(n int)